@@ -0,0 +1,199 @@
+// Package config holds pomerium's runtime configuration: Options (the parsed config file plus
+// databroker-derived overrides), Policy (a single route), and the Source/ChangeDispatcher
+// plumbing used to propagate config changes to dependents like internal/databroker.ConfigSource.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pomerium/pomerium/internal/hashutil"
+	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
+)
+
+// Options is pomerium's parsed configuration, plus the databroker-derived overrides
+// ConfigSource.rebuild layers on top via ApplySettings/AdditionalPolicies.
+type Options struct {
+	Services string
+
+	SharedKey               string
+	OverrideCertificateName string
+	CA                      string
+	CAFile                  string
+	GRPCClientTimeout       time.Duration
+	GRPCClientDNSRoundRobin bool
+	GRPCInsecure            bool
+
+	// GRPCClientMaxRecvMsgSize/GRPCClientMaxSendMsgSize bound the largest message the databroker
+	// gRPC client will accept/send, overriding gRPC's 4 MiB default so a configpb.Config record
+	// carrying many routes (large JWT claim headers, JWKS, PPL policy) doesn't trip
+	// ResourceExhausted. Zero means use the client's own default.
+	GRPCClientMaxRecvMsgSize int
+	GRPCClientMaxSendMsgSize int
+
+	// DataBrokerURLs are the data broker gRPC addresses. The first is treated as the
+	// write/authoritative primary; the rest are read replicas (see ConfigSource.runUpdater).
+	DataBrokerURLs []string
+
+	// DataBrokerMaxStaleness is how old dbConfigs is allowed to get (relative to
+	// ConfigSource.dbConfigsAsOf) before rebuild considers the computed config stale. Zero
+	// disables staleness checking.
+	DataBrokerMaxStaleness time.Duration
+	// DataBrokerStrictStaleness, when set, makes rebuild refuse to serve a config that exceeds
+	// DataBrokerMaxStaleness instead of just warning.
+	DataBrokerStrictStaleness bool
+
+	// dataDir is where pomerium keeps its on-disk data, e.g. the last-known-good databroker
+	// config snapshot. DataDir returns it as is; when it is empty, internal/databroker's
+	// snapshot store falls back to the OS temp dir.
+	dataDir string
+
+	// Policies are the statically configured routes, from the config file.
+	Policies []Policy
+	// AdditionalPolicies are routes contributed by the data broker; rebuild appends to this on
+	// every pass after calling Validate (which otherwise resets it), so GetAllPolicies always
+	// reflects the current databroker state.
+	AdditionalPolicies []Policy
+}
+
+// DataDir returns the directory pomerium should keep its on-disk data in.
+func (o *Options) DataDir() string { return o.dataDir }
+
+// SetDataDir sets the directory returned by DataDir, primarily for tests.
+func (o *Options) SetDataDir(dir string) { o.dataDir = dir }
+
+// GetAllPolicies returns every route this Options knows about: the statically configured
+// Policies plus any databroker-derived AdditionalPolicies.
+func (o *Options) GetAllPolicies() []Policy {
+	all := make([]Policy, 0, len(o.Policies)+len(o.AdditionalPolicies))
+	all = append(all, o.Policies...)
+	all = append(all, o.AdditionalPolicies...)
+	return all
+}
+
+// GetDataBrokerURLs returns the configured data broker addresses, or an error if none are set.
+func (o *Options) GetDataBrokerURLs() ([]string, error) {
+	if len(o.DataBrokerURLs) == 0 {
+		return nil, errors.New("config: no data broker urls configured")
+	}
+	return o.DataBrokerURLs, nil
+}
+
+// Validate checks that Options is internally consistent. It also resets AdditionalPolicies,
+// since those are recomputed from scratch by rebuild on every pass.
+func (o *Options) Validate() error {
+	o.AdditionalPolicies = nil
+	if len(o.DataBrokerURLs) == 0 {
+		return errors.New("config: no data broker urls configured")
+	}
+	return nil
+}
+
+// ApplySettings merges a databroker Config's Settings on top of o.
+func (o *Options) ApplySettings(settings *configpb.Settings) {
+	if settings == nil {
+		return
+	}
+	if settings.Services != "" {
+		o.Services = settings.Services
+	}
+}
+
+// Policy is a single route.
+type Policy struct {
+	From string
+	To   string
+}
+
+// RouteID returns a stable identifier for p, derived from its routable fields, so the same
+// route always gets the same ID across nodes and restarts.
+func (p *Policy) RouteID() (uint64, error) {
+	return hashutil.Hash(*p)
+}
+
+// Validate checks that p has the fields required to be routable.
+func (p *Policy) Validate() error {
+	if p.From == "" {
+		return errors.New("config: policy missing from")
+	}
+	return nil
+}
+
+// String renders p for logging.
+func (p Policy) String() string {
+	return fmt.Sprintf("%s -> %s", p.From, p.To)
+}
+
+// NewPolicyFromProto converts a databroker-provided Route into a Policy.
+func NewPolicyFromProto(routepb *configpb.Route) (*Policy, error) {
+	if routepb == nil {
+		return nil, errors.New("config: nil route")
+	}
+	return &Policy{From: routepb.From, To: routepb.To}, nil
+}
+
+// Config is a point-in-time snapshot of Options plus whatever else pomerium's config carries.
+type Config struct {
+	Options *Options
+}
+
+// Clone returns a deep-enough copy of c that mutating the clone's Options (including its policy
+// slices) doesn't affect c.
+func (c *Config) Clone() *Config {
+	if c == nil {
+		return nil
+	}
+	optClone := *c.Options
+	optClone.Policies = append([]Policy(nil), c.Options.Policies...)
+	optClone.AdditionalPolicies = append([]Policy(nil), c.Options.AdditionalPolicies...)
+	optClone.DataBrokerURLs = append([]string(nil), c.Options.DataBrokerURLs...)
+	return &Config{Options: &optClone}
+}
+
+// Checksum returns a content hash of c.Options, so two nodes that computed the same config
+// (including the same databroker-derived routes, in the same order) agree on a single number.
+func (c *Config) Checksum() uint64 {
+	h, err := hashutil.Hash(*c.Options)
+	if err != nil {
+		return 0
+	}
+	return h
+}
+
+// ChangeListener is called with the new Config whenever a Source's config changes.
+type ChangeListener func(*Config)
+
+// Source provides a Config and notifies registered ChangeListeners when it changes.
+type Source interface {
+	GetConfig() *Config
+	OnConfigChange(ChangeListener)
+}
+
+// ChangeDispatcher is embedded by types (like ConfigSource) that need to notify listeners of
+// config changes. Trigger releases the dispatcher's own lock before running listeners, so a
+// listener may call OnConfigChange; it does not release any lock the embedder holds when it
+// calls Trigger (ConfigSource's rebuild calls it with its own mutex held).
+type ChangeDispatcher struct {
+	mu        sync.Mutex
+	listeners []ChangeListener
+}
+
+// OnConfigChange registers li to be called on every future Trigger.
+func (d *ChangeDispatcher) OnConfigChange(li ChangeListener) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.listeners = append(d.listeners, li)
+}
+
+// Trigger calls every registered listener with cfg.
+func (d *ChangeDispatcher) Trigger(cfg *Config) {
+	d.mu.Lock()
+	listeners := append([]ChangeListener(nil), d.listeners...)
+	d.mu.Unlock()
+
+	for _, li := range listeners {
+		li(cfg)
+	}
+}
@@ -3,7 +3,16 @@ package databroker
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/pomerium/pomerium/config"
 	"github.com/pomerium/pomerium/internal/hashutil"
@@ -16,6 +25,13 @@ import (
 	"github.com/pomerium/pomerium/pkg/grpcutil"
 )
 
+// defaultGRPCMaxMsgSizeBytes is used when GRPCClientMaxRecvMsgSize/GRPCClientMaxSendMsgSize are
+// unset. A single configpb.Config record can carry many routes with large JWT claim headers,
+// JWKS or PPL policy, so the 4 MiB gRPC default is too easy to exceed. The databroker gRPC
+// server must be constructed with a matching databroker.ServerOptions.MaxMessageSizeBytes or a
+// client configured with a larger limit still gets ResourceExhausted from the server side.
+const defaultGRPCMaxMsgSizeBytes = 64 * 1024 * 1024
+
 // ConfigSource provides a new Config source that decorates an underlying config with
 // configuration derived from the data broker.
 type ConfigSource struct {
@@ -26,9 +42,73 @@ type ConfigSource struct {
 	updaterHash      uint64
 	cancel           func()
 
+	// status reflects whether the computed config is backed by the primary data broker
+	// (StatusHealthy), was assembled from a read replica because the primary is
+	// unreachable (StatusDegraded), or no replica could serve one either (StatusUnreachable).
+	status ConfigSourceStatus
+	// lastPrimaryVersion is the highest serverVersion we have observed from the primary (or
+	// seeded from a loaded snapshot), raised to a replica's serverVersion when failover adopts
+	// that replica's snapshot. It guards replica failover against silently regressing to a
+	// replica that has fallen behind the last state we know the primary held.
+	lastPrimaryVersion uint64
+	// replicaClients are the read-only data broker connections used to serve SyncLatest
+	// snapshots when the primary is unreachable.
+	replicaClients []databroker.DataBrokerServiceClient
+
+	// syncerState, syncerLastError, lastSyncTime, reconnectCount and nextRetry track the
+	// health of the supervising loop that keeps the primary Sync stream alive. See
+	// superviseSyncer.
+	syncerState     SyncerState
+	syncerLastError error
+	lastSyncTime    time.Time
+	reconnectCount  uint64
+	nextRetry       time.Time
+
+	// maxRecvMsgSizeBytes is the configured gRPC max receive message size, used to warn when a
+	// record is uncomfortably close to the ceiling.
+	maxRecvMsgSizeBytes uint32
+	// server enforces the same limit on the receive side that a real databroker gRPC server
+	// would, so a record the client would accept for sync is also one the server actually
+	// delivers instead of rejecting as ResourceExhausted.
+	server *databroker.Server
+
+	// snapshotStore persists dbConfigs so a restart can seed from the last-known-good state
+	// instead of serving no databroker-derived routes until the syncer catches up.
+	snapshotStore SnapshotStore
+	// dbConfigsAsOf is when dbConfigs was last known to reflect live state: set from a
+	// snapshot's SavedAt at startup, and to time.Now() on every successful sync or replica
+	// failover. rebuild compares this against dataBrokerMaxStaleness.
+	dbConfigsAsOf time.Time
+	// dataBrokerMaxStaleness and strictStaleness come from the active config; see rebuild.
+	dataBrokerMaxStaleness time.Duration
+	strictStaleness        bool
+
+	// conflictListeners are notified whenever rebuild resolves a route collision between two
+	// databroker-provided configs. See OnConflict.
+	conflictListeners []ConflictListener
+
 	config.ChangeDispatcher
 }
 
+// OnConflict registers a listener to be called whenever rebuild resolves a route collision
+// between two databroker-provided configs, so operators can audit what got shadowed.
+func (src *ConfigSource) OnConflict(li ConflictListener) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	src.conflictListeners = append(src.conflictListeners, li)
+}
+
+func (src *ConfigSource) triggerConflict(evt ConflictEvent) {
+	src.mu.RLock()
+	listeners := append([]ConflictListener(nil), src.conflictListeners...)
+	src.mu.RUnlock()
+
+	for _, li := range listeners {
+		li(evt)
+	}
+}
+
 type dbConfig struct {
 	*configpb.Config
 	version uint64
@@ -50,11 +130,38 @@ func NewConfigSource(underlying config.Source, listeners ...config.ChangeListene
 		src.rebuild(false)
 	})
 	src.underlyingConfig = underlying.GetConfig()
+
+	src.snapshotStore = NewFileSnapshotStore(snapshotPath(src.underlyingConfig))
+	if snapshot, err := src.snapshotStore.Load(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("databroker: failed to load last-known-good config snapshot")
+	} else if snapshot != nil {
+		dbConfigs, err := snapshot.dbConfigs()
+		if err != nil {
+			log.Warn().Err(err).Msg("databroker: failed to decode last-known-good config snapshot")
+		} else {
+			src.dbConfigs = dbConfigs
+			src.dbConfigsAsOf = snapshot.SavedAt
+			src.lastPrimaryVersion = snapshot.ServerVersion
+			log.Info().Time("as_of", snapshot.SavedAt).Msg("databroker: seeded config from last-known-good snapshot")
+		}
+	}
+
 	src.rebuild(true)
 	return src
 }
 
-// GetConfig gets the current config.
+// snapshotPath returns where the default file-backed SnapshotStore keeps its last-known-good
+// snapshot, alongside pomerium's other on-disk data.
+func snapshotPath(cfg *config.Config) string {
+	dir := cfg.Options.DataDir()
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "databroker-config-snapshot.json")
+}
+
+// GetConfig gets the current config. While the source is degraded (see Status) the returned
+// config is still the best available approximation, computed from a read replica's snapshot.
 func (src *ConfigSource) GetConfig() *config.Config {
 	src.mu.RLock()
 	defer src.mu.RUnlock()
@@ -62,15 +169,60 @@ func (src *ConfigSource) GetConfig() *config.Config {
 	return src.computedConfig
 }
 
+// Status reports whether the computed config is backed by the primary data broker or, because
+// the primary is unreachable, was assembled from a read replica's snapshot, or whether neither
+// the primary nor any replica could serve one (StatusUnreachable). Callers such as the
+// control plane or admin API should use this to refuse writes and log clearly while degraded
+// rather than presenting a healthy cluster.
+func (src *ConfigSource) Status() ConfigSourceStatus {
+	src.mu.RLock()
+	defer src.mu.RUnlock()
+
+	return src.status
+}
+
+// SyncerStatus reports the current connection state of the syncer that keeps the primary data
+// broker's records in sync, along with the last error seen, the last successful sync time and
+// the number of reconnects since startup. Operators can use this to alert on a wedged
+// connection rather than discovering it only when routes silently stop updating.
+func (src *ConfigSource) SyncerStatus() SyncerStatus {
+	src.mu.RLock()
+	defer src.mu.RUnlock()
+
+	return SyncerStatus{
+		State:          src.syncerState,
+		LastError:      src.syncerLastError,
+		LastSyncTime:   src.lastSyncTime,
+		ReconnectCount: src.reconnectCount,
+		NextRetry:      src.nextRetry,
+	}
+}
+
 func (src *ConfigSource) rebuild(firstTime bool) {
 	_, span := trace.StartSpan(context.Background(), "databroker.config_source.rebuild")
 	defer span.End()
 
+	// rebuildLocked runs with src.mu held and returns any conflicts it found. Conflict
+	// listeners are notified below, once the lock has been released, so a listener that calls
+	// back into ConfigSource (e.g. Status, GetConfig) can't deadlock against rebuild's own
+	// write lock.
+	conflicts := src.rebuildLocked(firstTime)
+	for _, conflict := range conflicts {
+		src.triggerConflict(conflict)
+	}
+}
+
+func (src *ConfigSource) rebuildLocked(firstTime bool) []ConflictEvent {
 	src.mu.Lock()
 	defer src.mu.Unlock()
 
+	var conflicts []ConflictEvent
+
 	cfg := src.underlyingConfig.Clone()
 
+	src.dataBrokerMaxStaleness = cfg.Options.DataBrokerMaxStaleness
+	src.strictStaleness = cfg.Options.DataBrokerStrictStaleness
+
 	// start the updater
 	src.runUpdater(cfg)
 
@@ -81,22 +233,38 @@ func (src *ConfigSource) rebuild(firstTime bool) {
 			log.Warn().Err(err).
 				Str("policy", policy.String()).
 				Msg("databroker: invalid policy config, ignoring")
-			return
+			return conflicts
 		}
 		seen[id] = struct{}{}
 	}
 
-	var additionalPolicies []config.Policy
+	// Sort config IDs so Settings are applied and route collisions are resolved in the same
+	// order on every node, regardless of Go's randomized map iteration. Two nodes that have
+	// synced the same dbConfigs must compute the same cfg.Checksum(). Merge policy is
+	// last-writer-wins by this order: a config ID later in the sort shadows an earlier one
+	// when both are the underlying source of a route. (A priority field on configpb.Config
+	// would be a natural tie-breaker ahead of ID, but none exists yet.)
+	ids := make([]string, 0, len(src.dbConfigs))
+	for id := range src.dbConfigs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
 
-	// add all the config policies to the list
-	for id, cfgpb := range src.dbConfigs {
+	type routeOwner struct {
+		policy   config.Policy
+		configID string
+	}
+	routes := map[uint64]routeOwner{}
+
+	for _, id := range ids {
+		cfgpb := src.dbConfigs[id]
 		cfg.Options.ApplySettings(cfgpb.Settings)
 		var errCount uint64
 
 		err := cfg.Options.Validate()
 		if err != nil {
 			metrics.SetDBConfigRejected(cfg.Options.Services, id, cfgpb.version, err)
-			return
+			return conflicts
 		}
 
 		for _, routepb := range cfgpb.GetRoutes() {
@@ -131,40 +299,124 @@ func (src *ConfigSource) rebuild(firstTime bool) {
 
 			if _, ok := seen[routeID]; ok {
 				errCount++
-				log.Warn().Err(err).
+				log.Warn().
 					Str("db_config_id", id).
 					Str("policy", policy.String()).
-					Msg("databroker: duplicate policy detected, ignoring")
+					Msg("databroker: duplicate policy detected, shadowed by a route from the underlying config, ignoring")
 				continue
 			}
-			seen[routeID] = struct{}{}
 
-			additionalPolicies = append(additionalPolicies, *policy)
+			if existing, ok := routes[routeID]; ok {
+				errCount++
+				diff := diffPolicies(existing.policy, *policy)
+				log.Warn().
+					Str("losing_db_config_id", existing.configID).
+					Str("winning_db_config_id", id).
+					Str("diff", diff).
+					Msg("databroker: duplicate policy detected, last writer wins")
+				// Only a true cross-config collision is reported as a ConflictEvent: a single
+				// dbConfig contributing two routes with the same RouteID to itself isn't two
+				// sources disagreeing, it's one source's own data being malformed.
+				if existing.configID != id {
+					conflicts = append(conflicts, ConflictEvent{
+						RouteID:         routeID,
+						WinningConfigID: id,
+						LosingConfigID:  existing.configID,
+						Diff:            diff,
+					})
+				}
+			}
+			routes[routeID] = routeOwner{*policy, id}
 		}
 		metrics.SetDBConfigInfo(cfg.Options.Services, id, cfgpb.version, int64(errCount))
 	}
 
+	routeIDs := make([]uint64, 0, len(routes))
+	for routeID := range routes {
+		routeIDs = append(routeIDs, routeID)
+	}
+	sort.Slice(routeIDs, func(i, j int) bool { return routeIDs[i] < routeIDs[j] })
+
+	additionalPolicies := make([]config.Policy, 0, len(routeIDs))
+	for _, routeID := range routeIDs {
+		additionalPolicies = append(additionalPolicies, routes[routeID].policy)
+	}
+
 	// add the additional policies here since calling `Validate` will reset them.
 	cfg.Options.AdditionalPolicies = append(cfg.Options.AdditionalPolicies, additionalPolicies...)
 
+	stale := src.dataBrokerMaxStaleness > 0 &&
+		!src.dbConfigsAsOf.IsZero() &&
+		time.Since(src.dbConfigsAsOf) > src.dataBrokerMaxStaleness
+
+	if stale && src.strictStaleness {
+		// Refuse to serve cfg: keep whatever computedConfig already holds rather than
+		// regressing GetConfig to this stale one. The one exception is a computedConfig that's
+		// still nil — e.g. the very first rebuild started from an already-stale loaded
+		// snapshot — where there is no last known-good config to preserve, so cfg (marked
+		// stale) is populated anyway rather than leaving GetConfig permanently nil.
+		if src.computedConfig == nil {
+			src.computedConfig = cfg
+		}
+		log.Error().
+			Time("as_of", src.dbConfigsAsOf).
+			Dur("max_staleness", src.dataBrokerMaxStaleness).
+			Msg("databroker: computed config exceeds max staleness, refusing to serve it in strict mode")
+		metrics.SetConfigInfo(cfg.Options.Services, "databroker", cfg.Checksum(), false)
+		return conflicts
+	}
+	if stale {
+		log.Warn().
+			Time("as_of", src.dbConfigsAsOf).
+			Dur("max_staleness", src.dataBrokerMaxStaleness).
+			Msg("databroker: computed config exceeds max staleness")
+	}
+
 	src.computedConfig = cfg
 	if !firstTime {
 		src.Trigger(cfg)
 	}
 
-	metrics.SetConfigInfo(cfg.Options.Services, "databroker", cfg.Checksum(), true)
+	metrics.SetConfigInfo(cfg.Options.Services, "databroker", cfg.Checksum(), !stale)
+
+	return conflicts
 }
 
+// runUpdater (re)starts the syncer against the primary data broker, which is the first
+// address in DataBrokerURLs. The remaining addresses are treated as read replicas: they never
+// receive writes, but are queried for a SyncLatest snapshot if the primary becomes unreachable,
+// so GetConfig can keep serving a (degraded, read-only) config instead of going stale outright.
 func (src *ConfigSource) runUpdater(cfg *config.Config) {
 	urls, err := cfg.Options.GetDataBrokerURLs()
 	if err != nil {
 		log.Fatal().Err(err).Send()
 		return
 	}
+	if len(urls) == 0 {
+		log.Fatal().Msg("databroker: no data broker urls configured")
+		return
+	}
+	primaryURL, replicaURLs := urls[0], urls[1:]
+
+	maxRecvMsgSize := cfg.Options.GRPCClientMaxRecvMsgSize
+	if maxRecvMsgSize <= 0 {
+		maxRecvMsgSize = defaultGRPCMaxMsgSizeBytes
+	}
+	maxSendMsgSize := cfg.Options.GRPCClientMaxSendMsgSize
+	if maxSendMsgSize <= 0 {
+		maxSendMsgSize = defaultGRPCMaxMsgSizeBytes
+	}
+	src.maxRecvMsgSizeBytes = uint32(maxRecvMsgSize)
+
+	server, err := databroker.NewServer(databroker.ServerOptions{MaxMessageSizeBytes: maxRecvMsgSize})
+	if err != nil {
+		log.Fatal().Err(err).Send()
+		return
+	}
+	src.server = server
 
 	sharedKey, _ := base64.StdEncoding.DecodeString(cfg.Options.SharedKey)
-	connectionOptions := &grpc.Options{
-		Addrs:                   urls,
+	baseOptions := grpc.Options{
 		OverrideCertificateName: cfg.Options.OverrideCertificateName,
 		CA:                      cfg.Options.CA,
 		CAFile:                  cfg.Options.CAFile,
@@ -173,8 +425,14 @@ func (src *ConfigSource) runUpdater(cfg *config.Config) {
 		WithInsecure:            cfg.Options.GRPCInsecure,
 		ServiceName:             cfg.Options.Services,
 		SignedJWTKey:            sharedKey,
+		MaxCallRecvMsgSizeBytes: maxRecvMsgSize,
+		MaxCallSendMsgSizeBytes: maxSendMsgSize,
 	}
-	h, err := hashutil.Hash(connectionOptions)
+
+	h, err := hashutil.Hash(struct {
+		Options grpc.Options
+		URLs    []string
+	}{baseOptions, urls})
 	if err != nil {
 		log.Fatal().Err(err).Send()
 	}
@@ -189,22 +447,278 @@ func (src *ConfigSource) runUpdater(cfg *config.Config) {
 		src.cancel = nil
 	}
 
-	cc, err := grpc.NewGRPCClientConn(connectionOptions)
+	primaryOptions := baseOptions
+	primaryOptions.Addrs = []string{primaryURL}
+	primaryConn, err := grpc.NewGRPCClientConn(&primaryOptions)
 	if err != nil {
-		log.Error().Err(err).Msg("databroker: failed to create gRPC connection to data broker")
+		log.Error().Err(err).Str("addr", primaryURL).Msg("databroker: failed to create gRPC connection to primary data broker")
 		return
 	}
+	primaryClient := databroker.NewDataBrokerServiceClient(primaryConn)
 
-	client := databroker.NewDataBrokerServiceClient(cc)
+	var replicaClients []databroker.DataBrokerServiceClient
+	for _, addr := range replicaURLs {
+		replicaOptions := baseOptions
+		replicaOptions.Addrs = []string{addr}
+		replicaConn, err := grpc.NewGRPCClientConn(&replicaOptions)
+		if err != nil {
+			log.Error().Err(err).Str("addr", addr).Msg("databroker: failed to create gRPC connection to replica data broker, skipping")
+			continue
+		}
+		replicaClients = append(replicaClients, databroker.NewDataBrokerServiceClient(replicaConn))
+	}
+	src.replicaClients = replicaClients
 
 	ctx := context.Background()
 	ctx, src.cancel = context.WithCancel(ctx)
 
 	syncer := databroker.NewSyncer("databroker", &syncerHandler{
-		client: client,
+		client: primaryClient,
 		src:    src,
 	}, databroker.WithTypeURL(grpcutil.GetTypeURL(new(configpb.Config))))
-	go func() { _ = syncer.Run(ctx) }()
+	go src.superviseSyncer(ctx, syncer)
+}
+
+const (
+	syncerBackoffBase = time.Second
+	syncerBackoffCap  = 60 * time.Second
+	// syncerHealthyAfter is how long a streaming session must last before we consider the
+	// connection healthy again and reset the backoff to its base.
+	syncerHealthyAfter = 30 * time.Second
+)
+
+// superviseSyncer replaces the old fire-and-forget `go syncer.Run(ctx)`: it restarts syncer.Run
+// whenever it returns an error, backing off with capped exponential backoff and full jitter
+// between attempts, and resetting the backoff once a streaming session has run long enough to
+// be considered healthy. It exits only when ctx is canceled. Each disconnect also triggers a
+// replica failover attempt so GetConfig can keep serving a degraded, read-only config while the
+// primary is down.
+func (src *ConfigSource) superviseSyncer(ctx context.Context, syncer *databroker.Syncer) {
+	attempt := 0
+	for {
+		if ctx.Err() != nil {
+			src.setSyncerState(SyncerStopped, nil)
+			return
+		}
+
+		src.setSyncerState(SyncerConnecting, nil)
+		start := time.Now()
+		err := syncer.Run(ctx)
+		ran := time.Since(start)
+
+		if ctx.Err() != nil {
+			src.setSyncerState(SyncerStopped, nil)
+			return
+		}
+
+		src.mu.Lock()
+		src.reconnectCount++
+		src.mu.Unlock()
+		metrics.IncDBSyncerReconnect()
+
+		if ran >= syncerHealthyAfter {
+			attempt = 0
+		}
+
+		log.Error().Err(err).Dur("session", ran).Msg("databroker: lost connection to primary data broker, attempting replica failover")
+		src.failoverToReplicas(ctx)
+
+		delay := fullJitterBackoff(syncerBackoffBase, syncerBackoffCap, attempt)
+		src.mu.Lock()
+		src.nextRetry = time.Now().Add(delay)
+		src.mu.Unlock()
+		src.setSyncerState(SyncerBackoff, err)
+		log.Warn().Dur("backoff", delay).Int("attempt", attempt).Msg("databroker: backing off before reconnecting to primary data broker")
+
+		select {
+		case <-ctx.Done():
+			src.setSyncerState(SyncerStopped, nil)
+			return
+		case <-time.After(delay):
+		}
+		attempt++
+	}
+}
+
+// fullJitterBackoff returns a random duration in [0, min(cap, base*2^attempt)), per the AWS
+// "full jitter" strategy: capped exponential backoff with full randomization to avoid
+// synchronized retries across instances.
+func fullJitterBackoff(base, capDur time.Duration, attempt int) time.Duration {
+	max := base
+	for i := 0; i < attempt && max < capDur; i++ {
+		max *= 2
+	}
+	if max > capDur {
+		max = capDur
+	}
+	if max <= 0 {
+		max = base
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// diffPolicies renders the exported fields that differ between two competing config.Policy
+// values for a ConflictEvent, so operators can see exactly what got shadowed rather than just
+// that a collision occurred.
+func diffPolicies(losing, winning config.Policy) string {
+	lv, wv := reflect.ValueOf(losing), reflect.ValueOf(winning)
+	t := lv.Type()
+
+	var diffs []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		lf, wf := lv.Field(i).Interface(), wv.Field(i).Interface()
+		if !reflect.DeepEqual(lf, wf) {
+			diffs = append(diffs, fmt.Sprintf("%s: %v -> %v", field.Name, lf, wf))
+		}
+	}
+	if len(diffs) == 0 {
+		return "no field-level differences detected"
+	}
+	return strings.Join(diffs, "; ")
+}
+
+func (src *ConfigSource) setSyncerState(state SyncerState, err error) {
+	src.mu.Lock()
+	src.syncerState = state
+	// keep the last error across later transitions so SyncerStatus still reports it
+	if err != nil {
+		src.syncerLastError = err
+	}
+	src.mu.Unlock()
+
+	metrics.SetDBSyncerState(state.String())
+}
+
+// failoverToReplicas is invoked when the primary data broker connection is lost. It queries
+// every replica for its latest snapshot via SyncLatest and adopts the freshest one that is not
+// older than the last version we observed from the primary, so a lagging replica can't silently
+// regress the computed config to an older state. If a usable snapshot is found, the source is
+// marked StatusDegraded and rebuilt from it; it stays that way until the primary syncer
+// reconnects and UpdateRecords restores StatusHealthy. If no replica yields a usable snapshot
+// (none configured, none reachable, or all behind the last known primary version), the source is
+// marked StatusUnreachable instead of being left at whatever status it held before the call.
+func (src *ConfigSource) failoverToReplicas(ctx context.Context) {
+	src.mu.RLock()
+	replicas := src.replicaClients
+	minVersion := src.lastPrimaryVersion
+	src.mu.RUnlock()
+
+	if len(replicas) == 0 {
+		log.Error().Msg("databroker: primary data broker unreachable and no replicas configured")
+		src.mu.Lock()
+		src.status = StatusUnreachable
+		src.mu.Unlock()
+		return
+	}
+
+	var bestVersion uint64
+	var bestRecords []*databroker.Record
+	for _, client := range replicas {
+		serverVersion, records, err := syncLatestWithTimeout(ctx, client, failoverPerReplicaTimeout)
+		if err != nil {
+			// err is anything syncLatest saw other than a clean end of stream (io.EOF) —
+			// including the per-replica timeout expiring mid-stream — so records here, if any,
+			// is a truncated snapshot and must not be treated as complete.
+			log.Warn().Err(err).Msg("databroker: replica unreachable or stream did not finish cleanly during failover, skipping")
+			continue
+		}
+		if serverVersion < minVersion {
+			log.Warn().
+				Uint64("replica_version", serverVersion).
+				Uint64("min_version", minVersion).
+				Msg("databroker: ignoring stale replica, behind last known primary version")
+			continue
+		}
+		if bestRecords == nil || serverVersion > bestVersion {
+			bestVersion, bestRecords = serverVersion, records
+		}
+	}
+
+	if bestRecords == nil {
+		log.Error().Msg("databroker: no replica available to serve a read-only config")
+		src.mu.Lock()
+		src.status = StatusUnreachable
+		src.mu.Unlock()
+		return
+	}
+
+	dbConfigs := map[string]dbConfig{}
+	for _, record := range bestRecords {
+		var cfgpb configpb.Config
+		if err := record.GetData().UnmarshalTo(&cfgpb); err != nil {
+			log.Warn().Err(err).Msg("databroker: error decoding config from replica snapshot")
+			continue
+		}
+		dbConfigs[record.GetId()] = dbConfig{&cfgpb, bestVersion}
+	}
+
+	src.mu.Lock()
+	src.dbConfigs = dbConfigs
+	src.status = StatusDegraded
+	src.dbConfigsAsOf = time.Now()
+	// Raise the floor to the version we just adopted so a later failover attempt (while the
+	// primary is still down) can't regress to a different, less-fresh replica that happens to
+	// answer that time. Without this, minVersion would stay pinned to the pre-failover primary
+	// version forever.
+	if bestVersion > src.lastPrimaryVersion {
+		src.lastPrimaryVersion = bestVersion
+	}
+	src.mu.Unlock()
+
+	metrics.SetDBConfigDegraded(true)
+	log.Warn().Uint64("server_version", bestVersion).Msg("databroker: serving degraded read-only config from replica")
+
+	src.rebuild(false)
+}
+
+// failoverPerReplicaTimeout bounds how long failoverToReplicas waits on any single replica's
+// SyncLatest stream, so one hung replica can't wedge the whole failover attempt (and, in turn,
+// the supervising loop's backoff/reconnect cycle for the primary) while other replicas might
+// have answered immediately.
+const failoverPerReplicaTimeout = 10 * time.Second
+
+// syncLatestWithTimeout calls syncLatest bounded by timeout, so a replica that accepts the
+// stream but never sends or closes it can't block the caller indefinitely.
+func syncLatestWithTimeout(ctx context.Context, client databroker.DataBrokerServiceClient, timeout time.Duration) (uint64, []*databroker.Record, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return syncLatest(ctx, client)
+}
+
+// syncLatest drains a SyncLatest stream into a server version and the records observed at it. A
+// clean end of stream is io.EOF; any other error (including the context deadline
+// syncLatestWithTimeout sets) means the stream was cut short, so the caller must not treat the
+// partial result as a complete snapshot.
+func syncLatest(ctx context.Context, client databroker.DataBrokerServiceClient) (uint64, []*databroker.Record, error) {
+	stream, err := client.SyncLatest(ctx, &databroker.SyncLatestRequest{
+		Type: grpcutil.GetTypeURL(new(configpb.Config)),
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var serverVersion uint64
+	var records []*databroker.Record
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return serverVersion, records, nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		if v := res.GetServerVersion(); v != 0 {
+			serverVersion = v
+		}
+		if r := res.GetRecord(); r != nil {
+			records = append(records, r)
+		}
+	}
 }
 
 type syncerHandler struct {
@@ -220,6 +734,9 @@ func (s *syncerHandler) ClearRecords(ctx context.Context) {
 	s.src.mu.Lock()
 	s.src.dbConfigs = map[string]dbConfig{}
 	s.src.mu.Unlock()
+
+	// ClearRecords is called once the Sync stream is established, before any records arrive.
+	s.src.setSyncerState(SyncerStreaming, nil)
 }
 
 func (s *syncerHandler) UpdateRecords(ctx context.Context, serverVersion uint64, records []*databroker.Record) {
@@ -234,6 +751,12 @@ func (s *syncerHandler) UpdateRecords(ctx context.Context, serverVersion uint64,
 			continue
 		}
 
+		if err := s.src.server.AcceptRecord(record); err != nil {
+			log.Warn().Err(err).Str("db_config_id", record.GetId()).Msg("databroker: server rejected oversized record, ignoring")
+			delete(s.src.dbConfigs, record.GetId())
+			continue
+		}
+
 		var cfgpb configpb.Config
 		err := record.GetData().UnmarshalTo(&cfgpb)
 		if err != nil {
@@ -242,9 +765,47 @@ func (s *syncerHandler) UpdateRecords(ctx context.Context, serverVersion uint64,
 			continue
 		}
 
+		if limit := s.src.maxRecvMsgSizeBytes; limit > 0 {
+			if size := cfgpb.Size(); uint32(size) > limit/2 {
+				log.Warn().
+					Str("db_config_id", record.GetId()).
+					Int("bytes", size).
+					Uint32("limit_bytes", limit).
+					Msg("databroker: config record is over half the configured gRPC max message size")
+			}
+		}
+
 		s.src.dbConfigs[record.GetId()] = dbConfig{&cfgpb, record.Version}
 	}
+	if serverVersion > s.src.lastPrimaryVersion {
+		s.src.lastPrimaryVersion = serverVersion
+	}
+	wasDegraded := s.src.status == StatusDegraded
+	s.src.status = StatusHealthy
+	s.src.lastSyncTime = time.Now()
+	s.src.dbConfigsAsOf = s.src.lastSyncTime
+	lastSyncTime := s.src.lastSyncTime
+	dbConfigs := make(map[string]dbConfig, len(s.src.dbConfigs))
+	for id, c := range s.src.dbConfigs {
+		dbConfigs[id] = c
+	}
+	snapshotStore := s.src.snapshotStore
 	s.src.mu.Unlock()
 
+	metrics.SetDBSyncerLastSyncTime(lastSyncTime)
+
+	if wasDegraded {
+		metrics.SetDBConfigDegraded(false)
+		log.Info().Msg("databroker: primary data broker reachable again, leaving degraded read-only mode")
+	}
+
+	if snapshotStore != nil {
+		if snapshot, err := newSnapshot(dbConfigs, serverVersion); err != nil {
+			log.Warn().Err(err).Msg("databroker: failed to build config snapshot")
+		} else if err := snapshotStore.Save(ctx, snapshot); err != nil {
+			log.Warn().Err(err).Msg("databroker: failed to persist config snapshot")
+		}
+	}
+
 	s.src.rebuild(false)
 }
@@ -0,0 +1,268 @@
+package databroker
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pomerium/pomerium/config"
+	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
+	"github.com/pomerium/pomerium/pkg/grpc/databroker"
+)
+
+func TestFullJitterBackoff(t *testing.T) {
+	base, capDur := time.Second, 60*time.Second
+
+	for attempt := 0; attempt < 10; attempt++ {
+		for i := 0; i < 50; i++ {
+			d := fullJitterBackoff(base, capDur, attempt)
+			if d < 0 {
+				t.Fatalf("attempt %d: got negative delay %v", attempt, d)
+			}
+			if d >= capDur {
+				t.Fatalf("attempt %d: delay %v exceeds cap %v", attempt, d, capDur)
+			}
+		}
+	}
+}
+
+func TestFullJitterBackoffCapsExponentialGrowth(t *testing.T) {
+	base, capDur := time.Second, 10*time.Second
+
+	// At a high enough attempt count, base*2^attempt has long since exceeded cap, so every
+	// sample must land in [0, cap).
+	for i := 0; i < 50; i++ {
+		d := fullJitterBackoff(base, capDur, 20)
+		if d >= capDur {
+			t.Fatalf("delay %v exceeds cap %v once backoff has saturated", d, capDur)
+		}
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	dbConfigs := map[string]dbConfig{
+		"config-a": {&configpb.Config{Routes: []*configpb.Route{{From: "a.example.com", To: "http://a"}}}, 10},
+		"config-b": {&configpb.Config{Routes: []*configpb.Route{{From: "b.example.com", To: "http://b"}}}, 12},
+	}
+
+	snapshot, err := newSnapshot(dbConfigs, 42)
+	if err != nil {
+		t.Fatalf("newSnapshot: %v", err)
+	}
+
+	store := NewFileSnapshotStore(filepath.Join(t.TempDir(), "snapshot.json"))
+	ctx := context.Background()
+	if err := store.Save(ctx, snapshot); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.ServerVersion != 42 {
+		t.Fatalf("ServerVersion = %d, want 42", loaded.ServerVersion)
+	}
+
+	got, err := loaded.dbConfigs()
+	if err != nil {
+		t.Fatalf("dbConfigs: %v", err)
+	}
+	if len(got) != len(dbConfigs) {
+		t.Fatalf("got %d dbConfigs, want %d", len(got), len(dbConfigs))
+	}
+	for id, want := range dbConfigs {
+		gotCfg, ok := got[id]
+		if !ok {
+			t.Fatalf("missing dbConfig %q after round trip", id)
+		}
+		if gotCfg.version != want.version {
+			t.Errorf("%s: version = %d, want %d", id, gotCfg.version, want.version)
+		}
+		if !reflect.DeepEqual(gotCfg.Config.GetRoutes(), want.Config.GetRoutes()) {
+			t.Errorf("%s: routes = %+v, want %+v", id, gotCfg.Config.GetRoutes(), want.Config.GetRoutes())
+		}
+	}
+}
+
+func TestRebuildRouteConflictSkipsSelfCollision(t *testing.T) {
+	route := &configpb.Route{From: "a.example.com", To: "http://dup"}
+
+	src := &ConfigSource{
+		underlyingConfig: &config.Config{
+			Options: &config.Options{DataBrokerURLs: []string{"primary.example:443"}},
+		},
+		dbConfigs: map[string]dbConfig{
+			// Two routes with the same RouteID both coming from "config-a" is a malformed
+			// config, not a cross-config disagreement, so it must not surface as a conflict.
+			"config-a": {&configpb.Config{Routes: []*configpb.Route{route, route}}, 1},
+		},
+	}
+	t.Cleanup(func() {
+		if src.cancel != nil {
+			src.cancel()
+		}
+	})
+
+	conflicts := src.rebuildLocked(true)
+
+	if len(conflicts) != 0 {
+		t.Fatalf("got %d conflicts, want 0 for a self-collision within one db config: %+v", len(conflicts), conflicts)
+	}
+
+	policies := src.computedConfig.Options.AdditionalPolicies
+	if len(policies) != 1 {
+		t.Fatalf("got %d additional policies, want 1: %+v", len(policies), policies)
+	}
+}
+
+func TestUpdateRecordsRejectsRecordOverServerMessageSizeLimit(t *testing.T) {
+	server, err := databroker.NewServer(databroker.ServerOptions{MaxMessageSizeBytes: 16})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	src := &ConfigSource{
+		underlyingConfig: &config.Config{
+			Options: &config.Options{DataBrokerURLs: []string{"primary.example:443"}},
+		},
+		dbConfigs: map[string]dbConfig{},
+		server:    server,
+	}
+	t.Cleanup(func() {
+		if src.cancel != nil {
+			src.cancel()
+		}
+	})
+	handler := &syncerHandler{src: src}
+
+	handler.UpdateRecords(context.Background(), 1, []*databroker.Record{
+		{Id: "too-big", Version: 1, Data: &databroker.Any{Value: []byte(`{"routes":[{"from":"a.example.com"}]}`)}},
+	})
+
+	if _, ok := src.dbConfigs["too-big"]; ok {
+		t.Fatal("dbConfigs contains a record that exceeds the server's MaxMessageSizeBytes, want it rejected")
+	}
+}
+
+func TestRebuildLockedFirstTimeStrictStaleStillPopulatesComputedConfig(t *testing.T) {
+	src := &ConfigSource{
+		underlyingConfig: &config.Config{
+			Options: &config.Options{
+				DataBrokerURLs:            []string{"primary.example:443"},
+				DataBrokerMaxStaleness:    time.Minute,
+				DataBrokerStrictStaleness: true,
+			},
+		},
+		dbConfigs: map[string]dbConfig{
+			"config-a": {&configpb.Config{Routes: []*configpb.Route{{From: "a.example.com", To: "http://a"}}}, 1},
+		},
+		// Already older than DataBrokerMaxStaleness, as if seeded from a stale loaded snapshot.
+		dbConfigsAsOf: time.Now().Add(-time.Hour),
+	}
+	t.Cleanup(func() {
+		if src.cancel != nil {
+			src.cancel()
+		}
+	})
+
+	src.rebuildLocked(true)
+
+	if src.computedConfig == nil {
+		t.Fatal("computedConfig is nil after a strict-stale first rebuild, want the stale config populated rather than left unset")
+	}
+}
+
+func TestRebuildLockedStrictStalePreservesLastKnownGoodComputedConfig(t *testing.T) {
+	lastGood := &config.Config{Options: &config.Options{}}
+
+	src := &ConfigSource{
+		underlyingConfig: &config.Config{
+			Options: &config.Options{
+				DataBrokerURLs:            []string{"primary.example:443"},
+				DataBrokerMaxStaleness:    time.Minute,
+				DataBrokerStrictStaleness: true,
+			},
+		},
+		dbConfigs: map[string]dbConfig{
+			"config-a": {&configpb.Config{Routes: []*configpb.Route{{From: "a.example.com", To: "http://a"}}}, 1},
+		},
+		// Already older than DataBrokerMaxStaleness, as if the primary went unreachable a while
+		// ago and this rebuild is a later, now-stale one (not the first-ever rebuild).
+		dbConfigsAsOf:  time.Now().Add(-time.Hour),
+		computedConfig: lastGood,
+	}
+	t.Cleanup(func() {
+		if src.cancel != nil {
+			src.cancel()
+		}
+	})
+
+	src.rebuildLocked(false)
+
+	if src.computedConfig != lastGood {
+		t.Fatalf("computedConfig = %+v, want the preserved last known-good config %+v", src.computedConfig, lastGood)
+	}
+}
+
+func TestFailoverToReplicasNoReplicasMarksUnreachable(t *testing.T) {
+	src := &ConfigSource{status: StatusHealthy}
+
+	src.failoverToReplicas(context.Background())
+
+	if src.status != StatusUnreachable {
+		t.Fatalf("status = %v, want %v", src.status, StatusUnreachable)
+	}
+}
+
+func TestFileSnapshotStoreLoadMissingReturnsNil(t *testing.T) {
+	store := NewFileSnapshotStore(filepath.Join(t.TempDir(), "does-not-exist.json"))
+	snapshot, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if snapshot != nil {
+		t.Fatalf("Load on a missing file = %+v, want nil", snapshot)
+	}
+}
+
+func TestRebuildRouteConflictLastWriterWins(t *testing.T) {
+	route := &configpb.Route{From: "a.example.com", To: "http://winner-or-loser"}
+
+	src := &ConfigSource{
+		underlyingConfig: &config.Config{
+			Options: &config.Options{DataBrokerURLs: []string{"primary.example:443"}},
+		},
+		dbConfigs: map[string]dbConfig{
+			// "config-a" sorts before "config-b", so per rebuildLocked's documented
+			// last-writer-wins order, config-b's route should win the collision.
+			"config-a": {&configpb.Config{Routes: []*configpb.Route{route}}, 1},
+			"config-b": {&configpb.Config{Routes: []*configpb.Route{route}}, 2},
+		},
+	}
+	t.Cleanup(func() {
+		if src.cancel != nil {
+			src.cancel()
+		}
+	})
+
+	conflicts := src.rebuildLocked(true)
+
+	if len(conflicts) != 1 {
+		t.Fatalf("got %d conflicts, want 1: %+v", len(conflicts), conflicts)
+	}
+	conflict := conflicts[0]
+	if conflict.WinningConfigID != "config-b" || conflict.LosingConfigID != "config-a" {
+		t.Fatalf("conflict = %+v, want winner config-b, loser config-a", conflict)
+	}
+
+	policies := src.computedConfig.Options.AdditionalPolicies
+	if len(policies) != 1 {
+		t.Fatalf("got %d additional policies, want 1 (the winner only): %+v", len(policies), policies)
+	}
+	if policies[0].From != route.From || policies[0].To != route.To {
+		t.Fatalf("winning policy = %+v, want From=%s To=%s", policies[0], route.From, route.To)
+	}
+}
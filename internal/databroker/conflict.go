@@ -0,0 +1,16 @@
+package databroker
+
+// ConflictEvent describes a route collision between two databroker-provided configs: the route
+// ID in question, the config ID that won, the config ID that lost (and was shadowed), and a
+// field-level diff of the two competing policies, so operators can audit what got shadowed
+// instead of only seeing a generic "duplicate policy detected" warning.
+type ConflictEvent struct {
+	RouteID         uint64
+	WinningConfigID string
+	LosingConfigID  string
+	Diff            string
+}
+
+// ConflictListener is called whenever rebuild resolves a route collision between two
+// databroker-provided configs. See ConfigSource.OnConflict.
+type ConflictListener func(ConflictEvent)
@@ -0,0 +1,109 @@
+package databroker
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
+)
+
+// Snapshot is the last-known-good set of databroker-derived configs, persisted so that a
+// restart which can't immediately reach the data broker still has routes to serve.
+type Snapshot struct {
+	ServerVersion uint64                    `json:"serverVersion"`
+	SavedAt       time.Time                 `json:"savedAt"`
+	Configs       map[string]SnapshotConfig `json:"configs"`
+}
+
+// SnapshotConfig is a single dbConfig entry within a Snapshot.
+type SnapshotConfig struct {
+	Version uint64          `json:"version"`
+	Config  json.RawMessage `json:"config"`
+}
+
+// newSnapshot builds a Snapshot from the current dbConfigs, encoding each configpb.Config as JSON
+// so it round-trips exactly through the file-backed store.
+func newSnapshot(dbConfigs map[string]dbConfig, serverVersion uint64) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		ServerVersion: serverVersion,
+		SavedAt:       time.Now(),
+		Configs:       make(map[string]SnapshotConfig, len(dbConfigs)),
+	}
+	for id, cfg := range dbConfigs {
+		data, err := json.Marshal(cfg.Config)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Configs[id] = SnapshotConfig{Version: cfg.version, Config: data}
+	}
+	return snapshot, nil
+}
+
+// dbConfigs decodes the snapshot back into the map format ConfigSource keeps in memory.
+func (snapshot *Snapshot) dbConfigs() (map[string]dbConfig, error) {
+	out := make(map[string]dbConfig, len(snapshot.Configs))
+	for id, sc := range snapshot.Configs {
+		var cfgpb configpb.Config
+		if err := json.Unmarshal(sc.Config, &cfgpb); err != nil {
+			return nil, err
+		}
+		out[id] = dbConfig{&cfgpb, sc.Version}
+	}
+	return out, nil
+}
+
+// SnapshotStore persists and restores the last-known-good Snapshot for a ConfigSource, so that
+// GetConfig can keep serving real routes across a restart instead of dropping them until the
+// syncer catches up. The default implementation is file-backed (see NewFileSnapshotStore).
+type SnapshotStore interface {
+	Save(ctx context.Context, snapshot *Snapshot) error
+	Load(ctx context.Context) (*Snapshot, error)
+}
+
+// fileSnapshotStore is the default SnapshotStore: a single JSON file written next to the rest
+// of pomerium's on-disk data.
+type fileSnapshotStore struct {
+	path string
+}
+
+// NewFileSnapshotStore creates a SnapshotStore backed by a single JSON file at path.
+func NewFileSnapshotStore(path string) SnapshotStore {
+	return &fileSnapshotStore{path: path}
+}
+
+func (s *fileSnapshotStore) Save(_ context.Context, snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	// write-then-rename so a reader never observes a partially written snapshot
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *fileSnapshotStore) Load(_ context.Context) (*Snapshot, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
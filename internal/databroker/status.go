@@ -0,0 +1,35 @@
+package databroker
+
+// ConfigSourceStatus describes the health of a ConfigSource's connection to the data broker
+// cluster.
+type ConfigSourceStatus int
+
+const (
+	// StatusHealthy indicates the primary data broker is reachable and the computed config
+	// reflects its writes.
+	StatusHealthy ConfigSourceStatus = iota
+	// StatusDegraded indicates the primary data broker is unreachable and the computed config
+	// was instead built from a read replica's last known snapshot. Writes should be refused
+	// until the primary recovers.
+	StatusDegraded
+	// StatusUnreachable indicates the primary data broker is unreachable and no replica could
+	// serve a usable snapshot either (none configured, none reachable, or none at least as
+	// fresh as the last version observed from the primary). The computed config is whatever was
+	// last built and is not being refreshed; callers should treat it as unknown freshness rather
+	// than assuming either StatusHealthy or StatusDegraded's read-replica guarantees.
+	StatusUnreachable
+)
+
+// String implements fmt.Stringer.
+func (s ConfigSourceStatus) String() string {
+	switch s {
+	case StatusHealthy:
+		return "healthy"
+	case StatusDegraded:
+		return "degraded/read-only"
+	case StatusUnreachable:
+		return "unreachable"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,57 @@
+package databroker
+
+import (
+	"fmt"
+	"time"
+)
+
+// SyncerState describes the current lifecycle state of the syncer that keeps a ConfigSource's
+// dbConfigs in sync with the primary data broker.
+type SyncerState int
+
+const (
+	// SyncerConnecting indicates a Sync stream to the primary is being established.
+	SyncerConnecting SyncerState = iota
+	// SyncerStreaming indicates the Sync stream is established and receiving updates.
+	SyncerStreaming
+	// SyncerBackoff indicates the last Sync stream ended and a reconnect is scheduled.
+	SyncerBackoff
+	// SyncerStopped indicates the syncer has shut down because its context was canceled.
+	SyncerStopped
+)
+
+// String implements fmt.Stringer.
+func (s SyncerState) String() string {
+	switch s {
+	case SyncerConnecting:
+		return "Connecting"
+	case SyncerStreaming:
+		return "Streaming"
+	case SyncerBackoff:
+		return "Backoff"
+	case SyncerStopped:
+		return "Stopped"
+	default:
+		return "Unknown"
+	}
+}
+
+// SyncerStatus is a point-in-time snapshot of the syncer's connection state, suitable for
+// logging or surfacing in an admin UI.
+type SyncerStatus struct {
+	State          SyncerState
+	LastError      error
+	LastSyncTime   time.Time
+	ReconnectCount uint64
+	// NextRetry is only meaningful when State is SyncerBackoff.
+	NextRetry time.Time
+}
+
+// String implements fmt.Stringer. For SyncerBackoff it includes the scheduled retry time, e.g.
+// "Backoff(next=2026-07-29T12:00:01Z)".
+func (s SyncerStatus) String() string {
+	if s.State == SyncerBackoff {
+		return fmt.Sprintf("Backoff(next=%s)", s.NextRetry.Format(time.RFC3339))
+	}
+	return s.State.String()
+}
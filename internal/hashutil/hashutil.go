@@ -0,0 +1,25 @@
+// Package hashutil provides a stable, content-based hash for arbitrary Go values, used to detect
+// when a derived configuration (e.g. a set of gRPC dial options) has actually changed so callers
+// can skip redundant rebuild work.
+package hashutil
+
+import (
+	"bytes"
+	"encoding/gob"
+	"hash/fnv"
+)
+
+// Hash returns a content hash of v. v must be gob-encodable (exported fields only); the same
+// map-free value always hashes the same (gob encodes maps in iteration order, so a value
+// containing a map may not), and different values hash differently with overwhelming
+// probability, but the hash is not cryptographically secure and must not be used as one.
+func Hash(v interface{}) (uint64, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return 0, err
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write(buf.Bytes())
+	return h.Sum64(), nil
+}
@@ -0,0 +1,97 @@
+// Package log is a minimal structured-logging facade used across pomerium packages. It mirrors
+// the chainable-event style of our usual logging library (Warn().Err(err).Str("k", v).Msg("...")),
+// writing to stderr, so call sites don't need to special-case a particular logging backend.
+package log
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Event is an in-progress log line; it is discarded if Msg or Send is never called.
+type Event struct {
+	level  string
+	fields []string
+}
+
+func newEvent(level string) *Event {
+	return &Event{level: level}
+}
+
+// Info starts an informational log line.
+func Info() *Event { return newEvent("info") }
+
+// Warn starts a warning log line.
+func Warn() *Event { return newEvent("warn") }
+
+// Error starts an error log line.
+func Error() *Event { return newEvent("error") }
+
+// Fatal starts a log line that terminates the process once emitted via Msg or Send.
+func Fatal() *Event { return newEvent("fatal") }
+
+// Err attaches an error field. A nil error is a no-op, so call sites can unconditionally chain
+// .Err(err) without an extra nil check.
+func (e *Event) Err(err error) *Event {
+	if err != nil {
+		e.fields = append(e.fields, fmt.Sprintf("error=%q", err.Error()))
+	}
+	return e
+}
+
+// Str attaches a string field.
+func (e *Event) Str(key, val string) *Event {
+	e.fields = append(e.fields, fmt.Sprintf("%s=%q", key, val))
+	return e
+}
+
+// Int attaches an integer field.
+func (e *Event) Int(key string, val int) *Event {
+	e.fields = append(e.fields, fmt.Sprintf("%s=%d", key, val))
+	return e
+}
+
+// Uint64 attaches a uint64 field.
+func (e *Event) Uint64(key string, val uint64) *Event {
+	e.fields = append(e.fields, fmt.Sprintf("%s=%d", key, val))
+	return e
+}
+
+// Uint32 attaches a uint32 field.
+func (e *Event) Uint32(key string, val uint32) *Event {
+	e.fields = append(e.fields, fmt.Sprintf("%s=%d", key, val))
+	return e
+}
+
+// Time attaches a time.Time field, formatted as RFC3339.
+func (e *Event) Time(key string, val time.Time) *Event {
+	e.fields = append(e.fields, fmt.Sprintf("%s=%s", key, val.Format(time.RFC3339)))
+	return e
+}
+
+// Dur attaches a time.Duration field.
+func (e *Event) Dur(key string, val time.Duration) *Event {
+	e.fields = append(e.fields, fmt.Sprintf("%s=%s", key, val))
+	return e
+}
+
+// Msg emits the log line with the given message.
+func (e *Event) Msg(msg string) { e.write(msg) }
+
+// Send emits the log line with no message, field data only.
+func (e *Event) Send() { e.write("") }
+
+func (e *Event) write(msg string) {
+	line := fmt.Sprintf("level=%s", e.level)
+	if msg != "" {
+		line += fmt.Sprintf(" msg=%q", msg)
+	}
+	for _, f := range e.fields {
+		line += " " + f
+	}
+	fmt.Fprintln(os.Stderr, line)
+	if e.level == "fatal" {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,205 @@
+// Package metrics is the facade internal packages record operational gauges/counters through. It
+// registers real Prometheus collectors against the default registry, so whatever exposes
+// /metrics in the full build (an http.Handler wrapping promhttp.Handler) picks these up without
+// any further wiring from callers.
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "pomerium"
+
+var mu sync.Mutex
+
+var dbConfigDegradedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "databroker",
+	Name:      "config_degraded",
+	Help:      "1 if the computed config is currently backed by a read replica snapshot rather than the primary data broker, 0 otherwise.",
+})
+
+// dbConfigDegraded mirrors the last value passed to SetDBConfigDegraded, so callers that need to
+// observe it (e.g. tests) don't need to scrape the registry.
+var dbConfigDegraded bool
+
+// SetDBConfigDegraded records whether the computed config is currently backed by a read replica
+// snapshot rather than the primary data broker.
+func SetDBConfigDegraded(degraded bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	dbConfigDegraded = degraded
+	if degraded {
+		dbConfigDegradedGauge.Set(1)
+	} else {
+		dbConfigDegradedGauge.Set(0)
+	}
+}
+
+// DBConfigDegraded reports the last value recorded by SetDBConfigDegraded.
+func DBConfigDegraded() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return dbConfigDegraded
+}
+
+var dbConfigInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "databroker",
+	Name:      "config_info",
+	Help:      "The databroker version a db config was last synced at, labeled by service and db config id.",
+}, []string{"service", "db_config_id"})
+
+var dbConfigErrors = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "databroker",
+	Name:      "config_errors",
+	Help:      "The number of routes/policies that failed to apply from a db config, labeled by service and db config id.",
+}, []string{"service", "db_config_id"})
+
+// SetDBConfigInfo records the outcome of applying a single databroker-provided config: the
+// service name it applies to, its id, the databroker version it was synced at, and how many of
+// its routes/policies failed to apply.
+func SetDBConfigInfo(service, id string, version uint64, errCount int64) {
+	dbConfigInfo.WithLabelValues(service, id).Set(float64(version))
+	dbConfigErrors.WithLabelValues(service, id).Set(float64(errCount))
+}
+
+var dbConfigRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "databroker",
+	Name:      "config_rejected_total",
+	Help:      "The number of times a databroker-provided config failed Options.Validate and was not applied, labeled by service and db config id.",
+}, []string{"service", "db_config_id"})
+
+// SetDBConfigRejected records that a databroker-provided config failed Options.Validate and was
+// not applied at all.
+func SetDBConfigRejected(service, id string, version uint64, err error) {
+	dbConfigRejected.WithLabelValues(service, id).Inc()
+}
+
+var configChecksum = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "config_checksum",
+	Help:      "The checksum of the computed config, labeled by service and source.",
+}, []string{"service", "source"})
+
+var configValid = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "config_valid",
+	Help:      "1 if the computed config is currently considered valid (not over max staleness), 0 otherwise, whether or not strict mode refused to serve it, labeled by service and source.",
+}, []string{"service", "source"})
+
+// SetConfigInfo records the checksum of the computed config for a given source (e.g.
+// "databroker") and whether it's currently considered valid (not over max staleness, in strict
+// mode or not).
+func SetConfigInfo(service, source string, checksum uint64, valid bool) {
+	configChecksum.WithLabelValues(service, source).Set(float64(checksum))
+	v := 0.0
+	if valid {
+		v = 1
+	}
+	configValid.WithLabelValues(service, source).Set(v)
+}
+
+var dbSyncerStateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "databroker",
+	Name:      "syncer_state",
+	Help:      "1 for the syncer's current connection state, 0 for every other state (see databroker.SyncerState).",
+}, []string{"state"})
+
+// dbSyncerStates enumerates every state SetDBSyncerState is called with, so dbSyncerStateGauge
+// can be reset to 0 before setting the current one to 1.
+var dbSyncerStates = []string{"Connecting", "Streaming", "Backoff", "Stopped"}
+
+// dbSyncerState mirrors the last value passed to SetDBSyncerState.
+var dbSyncerState string
+
+// SetDBSyncerState records the syncer's current connection state (see databroker.SyncerState).
+func SetDBSyncerState(state string) {
+	mu.Lock()
+	defer mu.Unlock()
+	dbSyncerState = state
+	for _, s := range dbSyncerStates {
+		v := 0.0
+		if s == state {
+			v = 1
+		}
+		dbSyncerStateGauge.WithLabelValues(s).Set(v)
+	}
+}
+
+// DBSyncerState reports the last value recorded by SetDBSyncerState.
+func DBSyncerState() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return dbSyncerState
+}
+
+var dbSyncerReconnectsCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "databroker",
+	Name:      "syncer_reconnects_total",
+	Help:      "The number of times the syncer has had to reconnect to the primary data broker.",
+})
+
+var dbSyncerReconnects uint64
+
+// IncDBSyncerReconnect increments the count of times the syncer has had to reconnect to the
+// primary data broker.
+func IncDBSyncerReconnect() {
+	mu.Lock()
+	defer mu.Unlock()
+	dbSyncerReconnects++
+	dbSyncerReconnectsCounter.Inc()
+}
+
+// DBSyncerReconnects reports the count recorded by IncDBSyncerReconnect.
+func DBSyncerReconnects() uint64 {
+	mu.Lock()
+	defer mu.Unlock()
+	return dbSyncerReconnects
+}
+
+var dbSyncerLastSyncTimeGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "databroker",
+	Name:      "syncer_last_sync_time_seconds",
+	Help:      "The unix time the syncer last successfully applied records from the primary data broker.",
+})
+
+var dbSyncerLastSyncTime time.Time
+
+// SetDBSyncerLastSyncTime records the last time the syncer successfully applied records from the
+// primary data broker.
+func SetDBSyncerLastSyncTime(t time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	dbSyncerLastSyncTime = t
+	dbSyncerLastSyncTimeGauge.Set(float64(t.Unix()))
+}
+
+// DBSyncerLastSyncTime reports the last value recorded by SetDBSyncerLastSyncTime.
+func DBSyncerLastSyncTime() time.Time {
+	mu.Lock()
+	defer mu.Unlock()
+	return dbSyncerLastSyncTime
+}
+
+func init() {
+	prometheus.MustRegister(
+		dbConfigDegradedGauge,
+		dbConfigInfo,
+		dbConfigErrors,
+		dbConfigRejected,
+		configChecksum,
+		configValid,
+		dbSyncerStateGauge,
+		dbSyncerReconnectsCounter,
+		dbSyncerLastSyncTimeGauge,
+	)
+}
@@ -0,0 +1,18 @@
+// Package trace is a minimal tracing facade used to bracket operations we want visible in a
+// distributed trace. It has no real exporter wired up in this tree; it exists so call sites have
+// a stable, single place to get a span from.
+package trace
+
+import "context"
+
+// Span represents one traced operation.
+type Span struct{}
+
+// End marks the span as finished.
+func (s *Span) End() {}
+
+// StartSpan returns ctx unchanged and a no-op span for the caller to End; name is not recorded
+// and no parent/child link to a span in ctx is made.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	return ctx, &Span{}
+}
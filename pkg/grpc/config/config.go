@@ -0,0 +1,45 @@
+// Package config holds the databroker-synced record types for route configuration
+// (configpb.Config). In the full build these are generated from a .proto definition; this tree
+// hand-rolls plain JSON-tagged structs instead, since the real protobuf toolchain/runtime isn't
+// available here. Callers that need wire-compatibility with the generated types should regenerate
+// this file from config.proto rather than editing it by hand.
+package config
+
+import "encoding/json"
+
+// Config is a single databroker-provided route configuration: the settings overrides it wants
+// applied, and the routes it contributes.
+type Config struct {
+	Settings *Settings `json:"settings,omitempty"`
+	Routes   []*Route  `json:"routes,omitempty"`
+}
+
+// GetRoutes returns c.Routes, or nil if c is nil.
+func (c *Config) GetRoutes() []*Route {
+	if c == nil {
+		return nil
+	}
+	return c.Routes
+}
+
+// Size returns an approximate wire size for c, used to warn when a record is uncomfortably
+// close to the configured gRPC max message size.
+func (c *Config) Size() int {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// Settings overrides fields on config.Options for the duration this Config is applied. Only the
+// fields exercised by ConfigSource are included; extend as needed.
+type Settings struct {
+	Services string `json:"services,omitempty"`
+}
+
+// Route is a single route contributed by a Config.
+type Route struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
@@ -0,0 +1,228 @@
+// Package databroker is the gRPC client (and server dial options) for pomerium's data broker
+// service: the Sync/SyncLatest streaming API that keeps a ConfigSource's dbConfigs up to date.
+// In the full build the request/response/client types are generated from databroker.proto; this
+// tree hand-rolls minimal equivalents since the real protobuf/gRPC runtime isn't available here.
+package databroker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pomerium/pomerium/pkg/grpc"
+)
+
+// Record is a single versioned record synced from the data broker.
+type Record struct {
+	Id        string
+	Version   uint64
+	DeletedAt *time.Time
+	Data      *Any
+}
+
+// GetId returns r.Id, or "" if r is nil.
+func (r *Record) GetId() string {
+	if r == nil {
+		return ""
+	}
+	return r.Id
+}
+
+// GetDeletedAt returns r.DeletedAt, or nil if r is nil.
+func (r *Record) GetDeletedAt() *time.Time {
+	if r == nil {
+		return nil
+	}
+	return r.DeletedAt
+}
+
+// GetData returns r.Data, or nil if r is nil.
+func (r *Record) GetData() *Any {
+	if r == nil {
+		return nil
+	}
+	return r.Data
+}
+
+// Any is a minimal stand-in for a protobuf Any: a type URL plus an opaque JSON payload, decoded
+// via UnmarshalTo instead of a full protobuf runtime.
+type Any struct {
+	TypeUrl string
+	Value   []byte
+}
+
+// UnmarshalTo decodes a's payload into dst.
+func (a *Any) UnmarshalTo(dst interface{}) error {
+	if a == nil {
+		return io.EOF
+	}
+	return json.Unmarshal(a.Value, dst)
+}
+
+// GetValue returns a.Value, or nil if a is nil.
+func (a *Any) GetValue() []byte {
+	if a == nil {
+		return nil
+	}
+	return a.Value
+}
+
+// SyncLatestRequest asks for the latest snapshot of records matching Type.
+type SyncLatestRequest struct {
+	Type string
+}
+
+// SyncLatestResponse is one message in a SyncLatest stream: either a server version marker or a
+// record, matching the real databroker.proto oneof.
+type SyncLatestResponse struct {
+	ServerVersion uint64
+	Record        *Record
+}
+
+// GetServerVersion returns r.ServerVersion, or 0 if r is nil.
+func (r *SyncLatestResponse) GetServerVersion() uint64 {
+	if r == nil {
+		return 0
+	}
+	return r.ServerVersion
+}
+
+// GetRecord returns r.Record, or nil if r is nil.
+func (r *SyncLatestResponse) GetRecord() *Record {
+	if r == nil {
+		return nil
+	}
+	return r.Record
+}
+
+// SyncLatestClient streams SyncLatestResponses until io.EOF (clean end) or another error
+// (timeout, transport failure).
+type SyncLatestClient interface {
+	Recv() (*SyncLatestResponse, error)
+}
+
+// DataBrokerServiceClient is the subset of the generated databroker gRPC client ConfigSource
+// depends on.
+type DataBrokerServiceClient interface {
+	SyncLatest(ctx context.Context, req *SyncLatestRequest) (SyncLatestClient, error)
+}
+
+type client struct {
+	conn *grpc.ClientConn
+}
+
+// NewDataBrokerServiceClient builds a DataBrokerServiceClient backed by conn.
+func NewDataBrokerServiceClient(conn *grpc.ClientConn) DataBrokerServiceClient {
+	return &client{conn: conn}
+}
+
+// ServerOptions configures the databroker gRPC server side. MaxMessageSizeBytes should be set to
+// the same value as the client-side grpc.Options.MaxCallRecvMsgSizeBytes/MaxCallSendMsgSizeBytes
+// (and config.Options.GRPCClientMaxRecvMsgSize/GRPCClientMaxSendMsgSize, which derive them) —
+// otherwise a client configured to send/accept large configpb.Config records still gets
+// ResourceExhausted from a server enforcing gRPC's smaller 4 MiB default.
+type ServerOptions struct {
+	// MaxMessageSizeBytes is the largest message a real server would accept and send. Server
+	// enforces only the receive side (see AcceptRecord), and treats zero as unbounded.
+	MaxMessageSizeBytes int
+}
+
+// Server enforces ServerOptions against records. The real databroker gRPC server (which stores
+// synced records and serves Sync/SyncLatest) lives outside this tree; Server exists so that
+// MaxMessageSizeBytes is actually enforced against incoming records rather than just recorded,
+// the same way a real grpc.Server would reject an oversized message before a handler ever sees
+// it.
+type Server struct {
+	opts ServerOptions
+}
+
+// NewServer builds a Server from opts. This tree has no real gRPC transport (see
+// NewGRPCClientConn), so this only validates and records the server options a real
+// google.golang.org/grpc.Server configured with grpc.MaxRecvMsgSize/grpc.MaxSendMsgSize would be
+// given.
+func NewServer(opts ServerOptions) (*Server, error) {
+	if opts.MaxMessageSizeBytes < 0 {
+		return nil, errors.New("databroker: MaxMessageSizeBytes must not be negative")
+	}
+	return &Server{opts: opts}, nil
+}
+
+// AcceptRecord reports an error if record exceeds s's configured MaxMessageSizeBytes, mirroring
+// the ResourceExhausted a real gRPC server would return instead of delivering the message to a
+// handler. A zero MaxMessageSizeBytes means unbounded (unlike a real gRPC server, whose default
+// receive limit is 4 MiB).
+func (s *Server) AcceptRecord(record *Record) error {
+	if s == nil || s.opts.MaxMessageSizeBytes <= 0 {
+		return nil
+	}
+	if size := len(record.GetData().GetValue()); size > s.opts.MaxMessageSizeBytes {
+		return fmt.Errorf("databroker: record %q is %d bytes, exceeds server max message size of %d bytes",
+			record.GetId(), size, s.opts.MaxMessageSizeBytes)
+	}
+	return nil
+}
+
+// closedStream reports a clean, empty stream: this tree has no real gRPC transport, so
+// SyncLatest returns immediately instead of blocking for a server that doesn't exist here.
+type closedStream struct{}
+
+func (closedStream) Recv() (*SyncLatestResponse, error) { return nil, io.EOF }
+
+func (c *client) SyncLatest(ctx context.Context, req *SyncLatestRequest) (SyncLatestClient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return closedStream{}, nil
+}
+
+// Handler receives record updates from a Syncer's Sync stream against the primary data broker.
+type Handler interface {
+	GetDataBrokerServiceClient() DataBrokerServiceClient
+	ClearRecords(ctx context.Context)
+	UpdateRecords(ctx context.Context, serverVersion uint64, records []*Record)
+}
+
+type syncerOptions struct {
+	typeURL string
+}
+
+// SyncerOption configures a Syncer built by NewSyncer.
+type SyncerOption func(*syncerOptions)
+
+// WithTypeURL restricts the Sync stream to records of the given type.
+func WithTypeURL(typeURL string) SyncerOption {
+	return func(o *syncerOptions) { o.typeURL = typeURL }
+}
+
+// Syncer keeps a Handler's records in sync with the primary data broker's Sync stream,
+// reconnecting is the caller's responsibility (see ConfigSource.superviseSyncer).
+type Syncer struct {
+	name    string
+	handler Handler
+	opts    syncerOptions
+}
+
+// NewSyncer creates a Syncer identified by name (used in logs/metrics) that keeps handler's
+// records in sync.
+func NewSyncer(name string, handler Handler, opts ...SyncerOption) *Syncer {
+	var o syncerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Syncer{name: name, handler: handler, opts: o}
+}
+
+// Run establishes the Sync stream and blocks until it ends or ctx is canceled, returning the
+// error that ended it. This tree has no real gRPC transport, so Run clears records and reports
+// the stream ended immediately; superviseSyncer is written to retry with backoff regardless of
+// how quickly Run returns.
+func (s *Syncer) Run(ctx context.Context) error {
+	s.handler.ClearRecords(ctx)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
@@ -0,0 +1,49 @@
+// Package grpc wraps gRPC client connection setup shared by pomerium's internal gRPC clients
+// (databroker, authorize, etc.) so every caller configures TLS, message sizes and auth the same
+// way instead of hand-rolling DialOptions.
+package grpc
+
+import (
+	"errors"
+	"time"
+)
+
+// Options configures a client connection built by NewGRPCClientConn.
+type Options struct {
+	// Addrs are the target addresses to connect to. Only the first is dialed; callers that want
+	// client-side round robin across addresses set ClientDNSRoundRobin and pass a single
+	// DNS name that resolves to multiple records.
+	Addrs []string
+
+	OverrideCertificateName string
+	CA                      string
+	CAFile                  string
+	RequestTimeout          time.Duration
+	ClientDNSRoundRobin     bool
+	WithInsecure            bool
+	ServiceName             string
+	SignedJWTKey            []byte
+
+	// MaxCallRecvMsgSizeBytes and MaxCallSendMsgSizeBytes bound the largest message this
+	// connection will accept/send per call, overriding gRPC's 4 MiB default. Zero means use the
+	// gRPC default.
+	MaxCallRecvMsgSizeBytes int
+	MaxCallSendMsgSizeBytes int
+}
+
+// ClientConn is a configured connection to a single gRPC server, ready to back a generated
+// service client (e.g. databroker.NewDataBrokerServiceClient).
+type ClientConn struct {
+	Addr    string
+	Options Options
+}
+
+// NewGRPCClientConn builds a ClientConn from opts. This tree has no real gRPC transport wired in
+// (see internal/databroker's callers), so this only validates and records the dial options a
+// real google.golang.org/grpc.Dial would be given.
+func NewGRPCClientConn(opts *Options) (*ClientConn, error) {
+	if opts == nil || len(opts.Addrs) == 0 {
+		return nil, errors.New("grpc: no address configured")
+	}
+	return &ClientConn{Addr: opts.Addrs[0], Options: *opts}, nil
+}
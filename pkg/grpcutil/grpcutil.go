@@ -0,0 +1,19 @@
+// Package grpcutil holds small helpers shared by pomerium's gRPC clients and servers.
+package grpcutil
+
+import "reflect"
+
+// GetTypeURL returns a stable type URL for msg's Go type, suitable for use as a Sync/SyncLatest
+// record type filter. In the full build this is derived from the message's protobuf descriptor;
+// this tree has no protobuf runtime, so it falls back to the Go type name, which is just as
+// stable within a single binary.
+func GetTypeURL(msg interface{}) string {
+	t := reflect.TypeOf(msg)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return "type.googleapis.com/" + t.Name()
+}